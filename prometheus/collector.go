@@ -1,8 +1,10 @@
 package prometheus
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,9 +19,31 @@ const (
 	KeyQueue        = "queue"
 	KeyAdaptors     = "adaptors"
 	KeyTxsWait      = "txs_wait"
-	KeyTxCost       = "tx_cost"
 	KeyTxsPerSecond = "txs_per_second"
 	KeyErrors       = "errors"
+
+	KeyPublishInflight   = "publish_inflight"
+	KeyPublishAckLatency = "publish_ack_latency"
+	KeyBackendErrors     = "backend_errors"
+
+	// KeyTxCostSeconds and KeyTxRelayDuration replace the old
+	// latest-value-only KeyTxCost gauge with a histogram and summary so
+	// relay cost tails are visible, not just the most recent sample.
+	KeyTxCostSeconds   = "tx_cost_seconds"
+	KeyTxRelayDuration = "tx_relay_duration_seconds"
+
+	// Dimensional metrics, labelled for per-subject breakdowns instead
+	// of the mostly-unlabeled gauges above. Per-adaptor metrics
+	// (adaptor_up, adaptor_height) belong here too, but land with
+	// whichever request adds an adaptor-side Probe to report them.
+	KeyTxRelayedTotal    = "tx_relayed_total"
+	KeyQueueDepth        = "queue_depth"
+	KeyNatsPublishErrors = "nats_publish_errors_total"
+
+	// KeyPublishRetries and KeyBreakerState back the retry-with-backoff
+	// and circuit breaker wrapped around Event2queue's publishes.
+	KeyPublishRetries = "publish_retries_total"
+	KeyBreakerState   = "breaker_state"
 )
 
 var collector *cassiniCollector
@@ -45,10 +69,14 @@ func init() {
 		fmt.Sprint(KeyPrefix, KeyTxsWait),
 		"Number of tx waiting to be relayed",
 		nil, nil)
-	collector.descs[KeyTxCost] = prometheus.NewDesc(
-		fmt.Sprint(KeyPrefix, KeyTxCost),
-		"Time(milliseconds) cost of lastest tx relay",
-		nil, nil)
+	collector.descs[KeyTxCostSeconds] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyTxCostSeconds),
+		"Histogram of tx relay cost(seconds)",
+		[]string{"from", "to"}, nil)
+	collector.descs[KeyTxRelayDuration] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyTxRelayDuration),
+		"Summary of tx relay duration(seconds) per chain pair",
+		[]string{"from", "to"}, nil)
 	collector.descs[KeyAdaptors] = prometheus.NewDesc(
 		fmt.Sprint(KeyPrefix, KeyAdaptors),
 		"Number of available adaptors",
@@ -58,6 +86,38 @@ func init() {
 		fmt.Sprint(KeyPrefix, KeyErrors),
 		"Count of running errors",
 		nil, nil)
+	collector.descs[KeyPublishInflight] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyPublishInflight),
+		"Number of in-flight JetStream publishes awaiting ack",
+		[]string{"subject"}, nil)
+	collector.descs[KeyPublishAckLatency] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyPublishAckLatency),
+		"Histogram of publish ack latency(seconds) by subject",
+		[]string{"subject"}, nil)
+	collector.descs[KeyBackendErrors] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyBackendErrors),
+		"Count of message queue backend errors",
+		[]string{"backend"}, nil)
+	collector.descs[KeyTxRelayedTotal] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyTxRelayedTotal),
+		"Count of relayed tx by outcome",
+		[]string{"from", "to", "status"}, nil)
+	collector.descs[KeyQueueDepth] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyQueueDepth),
+		"Depth of the relay queue for a subject",
+		[]string{"subject"}, nil)
+	collector.descs[KeyNatsPublishErrors] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyNatsPublishErrors),
+		"Count of NATS publish errors by subject and reason",
+		[]string{"subject", "reason"}, nil)
+	collector.descs[KeyPublishRetries] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyPublishRetries),
+		"Count of Event2queue publish retries",
+		[]string{"subject"}, nil)
+	collector.descs[KeyBreakerState] = prometheus.NewDesc(
+		fmt.Sprint(KeyPrefix, KeyBreakerState),
+		"Circuit breaker state per subject(0=closed,1=open,2=half-open)",
+		[]string{"subject"}, nil)
 
 	txsSecMetric = &CassiniMetric{
 		value: 0,
@@ -68,7 +128,6 @@ func init() {
 	SetGauge(KeyQueue, 0)
 	// SetGauge(KeyAdaptors, 0)
 	SetGauge(KeyTxsWait, 0)
-	SetGauge(KeyTxCost, 0)
 	Set(KeyTxsPerSecond, txsSecMetric)
 	Count(KeyErrors, 0)
 
@@ -130,6 +189,18 @@ func (m *CassiniMetric) Count(increase float64) {
 	m.value += increase
 }
 
+// export implements exportable.
+func (m *CassiniMetric) export(ch chan<- prometheus.Metric, desc *prometheus.Desc) {
+	ch <- prometheus.MustNewConstMetric(desc, m.Type, m.Value(), m.LabelValues...)
+}
+
+// exportable is implemented by every metric type the collector can
+// emit: CassiniMetric (gauges/counters), CassiniHistogram and
+// CassiniSummary.
+type exportable interface {
+	export(ch chan<- prometheus.Metric, desc *prometheus.Desc)
+}
+
 // Collector returns a collector
 // which exports metrics about status code of network service response
 func Collector(ch chan<- error) prometheus.Collector {
@@ -157,6 +228,10 @@ func (c *cassiniCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect returns the current state of all metrics of the collector.
 func (c *cassiniCollector) Collect(ch chan<- prometheus.Metric) {
+	updateProbes(context.Background(), func(err error) {
+		c.ch <- err
+	})
+
 	exports := func(k, v interface{}) bool {
 		key, ok := k.(string)
 		if !ok {
@@ -165,22 +240,23 @@ func (c *cassiniCollector) Collect(ch chan<- prometheus.Metric) {
 				key, ") into a string")
 			return true
 		}
-		var metric *CassiniMetric
-		metric, ok = v.(*CassiniMetric)
-		if !ok {
-			var metrics []*CassiniMetric
-			metrics, ok = v.([]*CassiniMetric)
-			if !ok {
-				c.ch <- fmt.Errorf("%s%s%s",
-					"Collect error: can not convert value(", key,
-					") into a *cassiniMetric or a []*cassiniMetric")
-				return true
-			}
-			for _, metric = range metrics {
+
+		switch m := v.(type) {
+		case exportable:
+			c.export(ch, key, m)
+		case []*CassiniMetric:
+			for _, metric := range m {
 				c.export(ch, key, metric)
 			}
-		} else {
-			c.export(ch, key, metric)
+		case *sync.Map:
+			m.Range(func(_, gv interface{}) bool {
+				c.export(ch, key, gv.(exportable))
+				return true
+			})
+		default:
+			c.ch <- fmt.Errorf("%s%s%s",
+				"Collect error: can not convert value(", key,
+				") into a known metric type")
 		}
 		return true
 	}
@@ -188,16 +264,13 @@ func (c *cassiniCollector) Collect(ch chan<- prometheus.Metric) {
 }
 
 func (c *cassiniCollector) export(ch chan<- prometheus.Metric,
-	key string, metric *CassiniMetric) {
+	key string, m exportable) {
 	desc, ok := c.descs[key]
 	if !ok {
 		c.ch <- fmt.Errorf("Collect error: can not find desc(%s)", key)
 		return
 	}
-	ch <- prometheus.MustNewConstMetric(
-		desc,
-		metric.Type,
-		metric.Value(), metric.LabelValues...)
+	m.export(ch, desc)
 }
 
 func (c *cassiniCollector) Set(key string, value interface{}) {
@@ -243,11 +316,84 @@ func Count(key string, increase float64) {
 	collector.Count(key, increase)
 }
 
+// labelsKey joins labelValues into a stable map key so distinct label
+// combinations under the same metric key don't overwrite each other.
+func labelsKey(labelValues []string) string {
+	return strings.Join(labelValues, "\x00")
+}
+
+func (c *cassiniCollector) group(key string) *sync.Map {
+	v, _ := c.mapper.LoadOrStore(key, &sync.Map{})
+	return v.(*sync.Map)
+}
+
+// SetLabeledGauge sets a gauge value for one label combination under
+// key, leaving other label combinations already stored under key
+// untouched. Use this instead of SetGauge when a key is reported with
+// more than one distinct set of labelValues (e.g. one per subject).
+func SetLabeledGauge(key string, value float64, labelValues ...string) {
+	group := collector.group(key)
+	lk := labelsKey(labelValues)
+	if v, ok := group.Load(lk); ok {
+		v.(*CassiniMetric).Set(value)
+		return
+	}
+	metric := &CassiniMetric{Type: prometheus.GaugeValue, LabelValues: labelValues}
+	metric.Set(value)
+	group.Store(lk, metric)
+}
+
+// CountLabeled increases a counter for one label combination under key,
+// leaving other label combinations already stored under key untouched.
+func CountLabeled(key string, increase float64, labelValues ...string) {
+	group := collector.group(key)
+	lk := labelsKey(labelValues)
+	if v, ok := group.Load(lk); ok {
+		v.(*CassiniMetric).Count(increase)
+		return
+	}
+	metric := &CassiniMetric{value: increase, Type: prometheus.CounterValue, LabelValues: labelValues}
+	group.Store(lk, metric)
+}
+
+// ObserveHistogram records value into the histogram for one label
+// combination under key, creating it with buckets on first use.
+func ObserveHistogram(key string, buckets []float64, value float64, labelValues ...string) {
+	group := collector.group(key)
+	lk := labelsKey(labelValues)
+	v, loaded := group.Load(lk)
+	if !loaded {
+		v, _ = group.LoadOrStore(lk, newHistogram(buckets, labelValues))
+	}
+	v.(*CassiniHistogram).Observe(value)
+}
+
+// ObserveSummary records value into the summary for one label
+// combination under key, creating it with objectives on first use.
+func ObserveSummary(key string, objectives map[float64]float64, value float64, labelValues ...string) {
+	group := collector.group(key)
+	lk := labelsKey(labelValues)
+	v, loaded := group.Load(lk)
+	if !loaded {
+		v, _ = group.LoadOrStore(lk, newSummary(objectives, labelValues))
+	}
+	v.(*CassiniSummary).Observe(value)
+}
+
 // TxCount the number of relayed tx
 func TxCount(increase float64) {
 	txsSecMetric.Count(increase)
 }
 
+// ObserveTxCost records the cost(seconds) of relaying a tx from chain
+// `from` to chain `to`, replacing the old latest-value-only TxCost
+// gauge with a histogram (for bucketed tail visibility) and a summary
+// (for alerting on p99 latency per bridge).
+func ObserveTxCost(from, to string, seconds float64) {
+	ObserveHistogram(KeyTxCostSeconds, DefaultCostBuckets, seconds, from, to)
+	ObserveSummary(KeyTxRelayDuration, DefaultRelayObjectives, seconds, from, to)
+}
+
 // StartMetrics prometheus exporter("/metrics") service
 func StartMetrics(ch chan<- error) {
 
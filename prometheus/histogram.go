@@ -0,0 +1,70 @@
+package prometheus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultCostBuckets are the tx_cost_seconds bucket upper bounds used
+// when a caller doesn't supply its own.
+var DefaultCostBuckets = []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultAckLatencyBuckets are the publish_ack_latency bucket upper
+// bounds used when a caller doesn't supply its own.
+var DefaultAckLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+// CassiniHistogram accumulates observations into fixed buckets for
+// export as a prometheus histogram.
+type CassiniHistogram struct {
+	LabelValues []string
+
+	mux     sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64, labelValues []string) *CassiniHistogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &CassiniHistogram{
+		LabelValues: labelValues,
+		buckets:     sorted,
+		counts:      make([]uint64, len(sorted)),
+	}
+}
+
+// Observe records v into the histogram, bumping every bucket whose
+// upper bound is >= v, as prometheus histograms expect.
+func (h *CassiniHistogram) Observe(v float64) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.count++
+	h.sum += v
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+}
+
+func (h *CassiniHistogram) snapshot() (count uint64, sum float64, buckets map[float64]uint64) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	buckets = make(map[float64]uint64, len(h.buckets))
+	for i, upper := range h.buckets {
+		buckets[upper] = h.counts[i]
+	}
+	return h.count, h.sum, buckets
+}
+
+// export implements exportable.
+func (h *CassiniHistogram) export(ch chan<- prometheus.Metric, desc *prometheus.Desc) {
+	count, sum, buckets := h.snapshot()
+	ch <- prometheus.MustNewConstHistogram(desc, count, sum, buckets, h.LabelValues...)
+}
@@ -0,0 +1,47 @@
+package prometheus
+
+import "testing"
+
+func TestHistogramObserveAccumulatesCumulativeBuckets(t *testing.T) {
+	h := newHistogram([]float64{1, 5, 10}, []string{"s"})
+
+	for _, v := range []float64{0.5, 3, 3, 8, 20} {
+		h.Observe(v)
+	}
+
+	count, sum, buckets := h.snapshot()
+	if count != 5 {
+		t.Fatalf("count = %d, want 5", count)
+	}
+	if sum != 0.5+3+3+8+20 {
+		t.Fatalf("sum = %v, want %v", sum, 0.5+3+3+8+20)
+	}
+
+	// Buckets are cumulative ("le" upper bound): each counts every
+	// observation <= its own bound, including those counted by the
+	// buckets below it.
+	want := map[float64]uint64{1: 1, 5: 3, 10: 4}
+	for upper, wantCount := range want {
+		if got := buckets[upper]; got != wantCount {
+			t.Errorf("bucket[%v] = %d, want %d", upper, got, wantCount)
+		}
+	}
+	// 20 exceeds every configured bound, so it's only reflected in
+	// count/sum, not in any bucket.
+}
+
+func TestHistogramSortsUnsortedBuckets(t *testing.T) {
+	h := newHistogram([]float64{10, 1, 5}, nil)
+	h.Observe(2)
+
+	_, _, buckets := h.snapshot()
+	if buckets[1] != 0 {
+		t.Fatalf("bucket[1] = %d, want 0 (2 > 1)", buckets[1])
+	}
+	if buckets[5] != 1 {
+		t.Fatalf("bucket[5] = %d, want 1 (2 <= 5)", buckets[5])
+	}
+	if buckets[10] != 1 {
+		t.Fatalf("bucket[10] = %d, want 1 (2 <= 10)", buckets[10])
+	}
+}
@@ -0,0 +1,37 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Probe lets a subsystem (route, adaptor, msgqueue, ...) contribute
+// metrics that the collector refreshes on every scrape, rather than
+// pushing values in as they happen.
+type Probe interface {
+	// Name identifies the probe, e.g. "route.pool" or "adaptor.eth".
+	Name() string
+	// Update refreshes whatever metrics this probe owns.
+	Update(ctx context.Context) error
+}
+
+var probes sync.Map // map[string]Probe
+
+// RegisterProbe registers p so the collector calls p.Update before
+// every scrape. Subsystems call this from their own init().
+func RegisterProbe(p Probe) {
+	probes.Store(p.Name(), p)
+}
+
+// updateProbes runs every registered probe, reporting failures through
+// onErr instead of aborting the scrape.
+func updateProbes(ctx context.Context, onErr func(error)) {
+	probes.Range(func(_, v interface{}) bool {
+		p := v.(Probe)
+		if err := p.Update(ctx); err != nil {
+			onErr(fmt.Errorf("probe %s update error: %v", p.Name(), err))
+		}
+		return true
+	})
+}
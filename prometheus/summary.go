@@ -0,0 +1,81 @@
+package prometheus
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultRelayObjectives are the tx_relay_duration_seconds quantile ->
+// allowed-error objectives used when a caller doesn't supply its own.
+var DefaultRelayObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+// summaryWindow bounds how many recent observations CassiniSummary
+// keeps to compute quantiles from, so memory stays flat under load.
+const summaryWindow = 1000
+
+// CassiniSummary keeps a bounded window of recent observations and
+// exports their quantiles as a prometheus summary.
+type CassiniSummary struct {
+	LabelValues []string
+
+	mux        sync.Mutex
+	objectives map[float64]float64
+	samples    []float64
+	next       int
+	count      uint64
+	sum        float64
+}
+
+func newSummary(objectives map[float64]float64, labelValues []string) *CassiniSummary {
+	return &CassiniSummary{
+		LabelValues: labelValues,
+		objectives:  objectives,
+		samples:     make([]float64, 0, summaryWindow),
+	}
+}
+
+// Observe records v into the summary.
+func (s *CassiniSummary) Observe(v float64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.count++
+	s.sum += v
+	if len(s.samples) < summaryWindow {
+		s.samples = append(s.samples, v)
+		return
+	}
+	s.samples[s.next] = v
+	s.next = (s.next + 1) % summaryWindow
+}
+
+func (s *CassiniSummary) snapshot() (count uint64, sum float64, quantiles map[float64]float64) {
+	s.mux.Lock()
+	sorted := append([]float64(nil), s.samples...)
+	count, sum = s.count, s.sum
+	objectives := s.objectives
+	s.mux.Unlock()
+
+	sort.Float64s(sorted)
+	quantiles = make(map[float64]float64, len(objectives))
+	for q := range objectives {
+		quantiles[q] = quantileOf(sorted, q)
+	}
+	return
+}
+
+func quantileOf(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(q * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// export implements exportable.
+func (s *CassiniSummary) export(ch chan<- prometheus.Metric, desc *prometheus.Desc) {
+	count, sum, quantiles := s.snapshot()
+	ch <- prometheus.MustNewConstSummary(desc, count, sum, quantiles, s.LabelValues...)
+}
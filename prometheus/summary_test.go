@@ -0,0 +1,59 @@
+package prometheus
+
+import "testing"
+
+func TestSummaryObserveTracksCountAndSum(t *testing.T) {
+	s := newSummary(map[float64]float64{0.5: 0.05}, nil)
+	for _, v := range []float64{1, 2, 3, 4} {
+		s.Observe(v)
+	}
+
+	count, sum, _ := s.snapshot()
+	if count != 4 {
+		t.Fatalf("count = %d, want 4", count)
+	}
+	if sum != 10 {
+		t.Fatalf("sum = %v, want 10", sum)
+	}
+}
+
+func TestSummaryQuantiles(t *testing.T) {
+	s := newSummary(map[float64]float64{0: 0, 0.5: 0.05, 1: 0}, nil)
+	for i := 1; i <= 100; i++ {
+		s.Observe(float64(i))
+	}
+
+	_, _, quantiles := s.snapshot()
+	if quantiles[0] != 1 {
+		t.Fatalf("p0 = %v, want 1 (the minimum)", quantiles[0])
+	}
+	if quantiles[1] != 100 {
+		t.Fatalf("p100 = %v, want 100 (the maximum)", quantiles[1])
+	}
+	if got := quantiles[0.5]; got < 49 || got > 51 {
+		t.Fatalf("p50 = %v, want close to the middle of 1..100", got)
+	}
+}
+
+func TestSummaryWindowIsBoundedAndRingBuffers(t *testing.T) {
+	s := newSummary(map[float64]float64{0: 0}, nil)
+
+	// Fill the window with small values, then push summaryWindow more,
+	// larger, values; the ring buffer should have fully evicted the
+	// small ones so even the min quantile reflects only the new values.
+	for i := 0; i < summaryWindow; i++ {
+		s.Observe(1)
+	}
+	for i := 0; i < summaryWindow; i++ {
+		s.Observe(1000)
+	}
+
+	if len(s.samples) != summaryWindow {
+		t.Fatalf("len(samples) = %d, want bounded at summaryWindow = %d", len(s.samples), summaryWindow)
+	}
+
+	_, _, quantiles := s.snapshot()
+	if quantiles[0] != 1000 {
+		t.Fatalf("p0 = %v, want 1000 once every original sample has been evicted", quantiles[0])
+	}
+}
@@ -0,0 +1,164 @@
+package route
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/QOSGroup/cassini/log"
+	"github.com/QOSGroup/cassini/prometheus"
+	amqp "github.com/streadway/amqp"
+)
+
+// AMQPTLS is the TLS client config used when dialing newly created AMQP
+// producers. It is populated from config before the first Event2queue
+// call; leave nil to dial plaintext amqp://. Credentials (user:pass)
+// are taken from the urls string itself, as amqp.Dial/DialTLS already
+// do; AMQPTLS only adds custom-CA/client-cert TLS beyond bare amqps://.
+var AMQPTLS *tls.Config
+
+func init() {
+	Register("amqp", newAMQPProducer)
+	Register("amqps", newAMQPProducer)
+}
+
+// cassiniExchange is the topic exchange cassini declares and publishes
+// to. The from2to subject is used as the routing key, so each chain
+// pair can be bound to its own queue downstream.
+const cassiniExchange = "cassini.events"
+
+// amqpProducer is the "amqp://" backend. It publishes with publisher
+// confirms enabled, so Produce only returns once the broker has
+// acknowledged the message.
+type amqpProducer struct {
+	urls string
+	tls  *tls.Config
+
+	mux     sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	confirm chan amqp.Confirmation
+
+	// publishMux serializes Publish+await-confirm pairs. The channel's
+	// single NotifyPublish stream has no way to correlate a
+	// Confirmation back to the call that produced it, so only one
+	// publish may be in flight at a time or concurrent callers can
+	// read back someone else's ack/nak.
+	publishMux sync.Mutex
+}
+
+func newAMQPProducer(urls string) Producer {
+	return &amqpProducer{urls: urls, tls: AMQPTLS}
+}
+
+// Connect dials the broker, opens a channel in confirm mode, and
+// declares the cassini events exchange.
+func (p *amqpProducer) Connect() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.conn != nil && !p.conn.IsClosed() {
+		return nil
+	}
+
+	dial := amqp.Dial
+	if p.tls != nil {
+		dial = func(urls string) (*amqp.Connection, error) {
+			return amqp.DialTLS(urls, p.tls)
+		}
+	}
+	conn, err := dial(p.urls)
+	if err != nil {
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "amqp")
+		return fmt.Errorf("route: couldn't connect to amqp server: %v", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "amqp")
+		return err
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		conn.Close()
+		return err
+	}
+
+	if err := channel.ExchangeDeclare(
+		cassiniExchange, "topic", true, false, false, false, nil,
+	); err != nil {
+		channel.Close()
+		conn.Close()
+		return err
+	}
+
+	p.conn = conn
+	p.channel = channel
+	p.confirm = channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	log.Infof("route: amqp producer connected to [%s]", p.urls)
+	return nil
+}
+
+// Produce publishes payload to the cassini events exchange, using
+// subject as the routing key, and waits for the publisher confirm.
+// Only one Publish+await-confirm pair runs at a time per channel (see
+// publishMux), so the Confirmation read back always belongs to this call.
+func (p *amqpProducer) Produce(ctx context.Context, subject string, payload []byte) error {
+	if err := p.Connect(); err != nil {
+		return err
+	}
+
+	p.publishMux.Lock()
+	defer p.publishMux.Unlock()
+
+	p.mux.Lock()
+	channel, confirm := p.channel, p.confirm
+	p.mux.Unlock()
+
+	start := time.Now()
+	err := channel.Publish(cassiniExchange, subject, false, false, amqp.Publishing{
+		ContentType:  "application/octet-stream",
+		Body:         payload,
+		DeliveryMode: amqp.Persistent,
+	})
+	if err != nil {
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "amqp")
+		return err
+	}
+
+	select {
+	case confirm := <-confirm:
+		if !confirm.Ack {
+			prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "amqp")
+			return fmt.Errorf("route: broker nacked publish to %s", subject)
+		}
+		prometheus.ObserveHistogram(prometheus.KeyPublishAckLatency, prometheus.DefaultAckLatencyBuckets, time.Since(start).Seconds(), subject)
+		return nil
+	case <-ctx.Done():
+		// Drop the channel so its pending confirmation can't be read
+		// back by the next publish; the next call reconnects.
+		p.Close()
+		return ctx.Err()
+	}
+}
+
+// Close releases the channel and connection.
+func (p *amqpProducer) Close() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.channel != nil {
+		p.channel.Close()
+		p.channel = nil
+	}
+	if p.conn != nil {
+		err := p.conn.Close()
+		p.conn = nil
+		return err
+	}
+	return nil
+}
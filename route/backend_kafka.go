@@ -0,0 +1,122 @@
+package route
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/QOSGroup/cassini/log"
+	"github.com/QOSGroup/cassini/prometheus"
+	kafka "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+)
+
+// KafkaAuth configures TLS and SASL for newly created Kafka producers.
+// It is populated from config before the first Event2queue call; leave
+// both fields nil/unset to dial brokers in plaintext with no auth.
+var KafkaAuth KafkaAuthOptions
+
+// KafkaAuthOptions bundles the transport-level security a Kafka
+// producer dials with.
+type KafkaAuthOptions struct {
+	// TLS is used as the broker connection's TLS config. Leave nil to
+	// dial plaintext.
+	TLS *tls.Config
+	// SASL, when set, authenticates every broker connection with this
+	// mechanism (e.g. a plain.Mechanism or scram.Mechanism from
+	// github.com/segmentio/kafka-go/sasl).
+	SASL sasl.Mechanism
+}
+
+func init() {
+	Register("kafka", newKafkaProducer)
+}
+
+// kafkaProducer is the "kafka://" backend. cassini's from2to subject
+// (e.g. "qos2eth") is used directly as the Kafka topic. It writes with
+// RequireAll acks so every publish has all in-sync replicas durable
+// before Produce returns.
+type kafkaProducer struct {
+	brokers []string
+	auth    KafkaAuthOptions
+
+	mux    sync.Mutex
+	writer *kafka.Writer
+}
+
+func newKafkaProducer(urls string) Producer {
+	return &kafkaProducer{brokers: brokers(urls), auth: KafkaAuth}
+}
+
+// brokers turns a "kafka://host1:9092,host2:9092" urls string into a
+// plain list of broker addresses.
+func brokers(urls string) []string {
+	urls = strings.TrimPrefix(urls, "kafka://")
+	return strings.Split(urls, ",")
+}
+
+// Connect prepares a Kafka writer. kafka-go dials lazily on the first
+// WriteMessages call, so this only validates that brokers were given.
+func (p *kafkaProducer) Connect() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.writer != nil {
+		return nil
+	}
+	if len(p.brokers) == 0 || p.brokers[0] == "" {
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "kafka")
+		return ErrNoBrokers
+	}
+	p.writer = &kafka.Writer{
+		Addr:         kafka.TCP(p.brokers...),
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireAll,
+		Async:        false,
+		Transport: &kafka.Transport{
+			TLS:  p.auth.TLS,
+			SASL: p.auth.SASL,
+		},
+	}
+	log.Infof("route: kafka producer connected to %v", p.brokers)
+	return nil
+}
+
+// Produce writes payload to the topic named subject, observing
+// publish latency labelled by subject.
+func (p *kafkaProducer) Produce(ctx context.Context, subject string, payload []byte) error {
+	if err := p.Connect(); err != nil {
+		return err
+	}
+
+	p.mux.Lock()
+	writer := p.writer
+	p.mux.Unlock()
+
+	start := time.Now()
+	err := writer.WriteMessages(ctx, kafka.Message{
+		Topic: subject,
+		Value: payload,
+	})
+	if err != nil {
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "kafka")
+		return err
+	}
+	prometheus.ObserveHistogram(prometheus.KeyPublishAckLatency, prometheus.DefaultAckLatencyBuckets, time.Since(start).Seconds(), subject)
+	return nil
+}
+
+// Close releases the Kafka writer's connections.
+func (p *kafkaProducer) Close() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.writer == nil {
+		return nil
+	}
+	err := p.writer.Close()
+	p.writer = nil
+	return err
+}
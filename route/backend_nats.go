@@ -0,0 +1,210 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/QOSGroup/cassini/log"
+	mq "github.com/QOSGroup/cassini/msgqueue"
+	"github.com/QOSGroup/cassini/prometheus"
+	"github.com/nats-io/nats.go"
+)
+
+func init() {
+	Register("nats", newNATSProducer)
+}
+
+// JetStreamOptions configures the optional JetStream at-least-once
+// publish path used by the NATS backend. Retrying a failed publish is
+// the caller's job (Event2queue wraps every Produce call in the
+// generic route.Policy retry, see retry.go), so this only bounds a
+// single publish attempt's wait for the broker's ack.
+type JetStreamOptions struct {
+	// Enabled switches the NATS backend from core NATS pub
+	// (fire-and-forget) to JetStream publish with PubAck confirmation.
+	Enabled bool
+	// MaxInflight bounds the number of unacknowledged PublishAsync calls
+	// allowed at once on the producer's JetStream context, across all
+	// subjects published through it.
+	MaxInflight int
+	// AckWait bounds how long a single publish waits for the broker's
+	// PubAck before it's treated as a timeout.
+	AckWait time.Duration
+}
+
+func (o JetStreamOptions) withDefaults() JetStreamOptions {
+	if o.MaxInflight <= 0 {
+		o.MaxInflight = 256
+	}
+	if o.AckWait <= 0 {
+		o.AckWait = 5 * time.Second
+	}
+	return o
+}
+
+// NATSJetStream controls whether newly created NATS producers enable
+// JetStream publishing. It is populated from config before the first
+// Event2queue call.
+var NATSJetStream JetStreamOptions
+
+// subjectState tracks per-subject JetStream publish bookkeeping.
+type subjectState struct {
+	mux      sync.Mutex
+	inflight int64
+}
+
+// natsProducer is the "nats://" backend registered with the route
+// Producer registry. It holds a single long-lived, health-checked
+// connection per broker urls, shared across every subject produced to.
+type natsProducer struct {
+	urls string
+	js   JetStreamOptions
+
+	mux      sync.Mutex
+	conn     *nats.Conn
+	jsCtx    nats.JetStreamContext
+	producer mq.NATSProducer
+	subjects sync.Map // map[string]*subjectState
+}
+
+func newNATSProducer(urls string) Producer {
+	return &natsProducer{
+		urls:     urls,
+		js:       NATSJetStream.withDefaults(),
+		producer: mq.NATSProducer{ServerUrls: urls},
+	}
+}
+
+// Connect lazily (re)connects to the NATS cluster, reusing the
+// connection while it stays healthy.
+func (p *natsProducer) Connect() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.conn != nil && p.conn.IsConnected() {
+		return nil
+	}
+
+	conn, err := p.producer.Connect()
+	if err != nil {
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "nats")
+		return fmt.Errorf("couldn't connect to msg server: %v", err)
+	}
+
+	if p.js.Enabled {
+		jsCtx, err := conn.JetStream(nats.PublishAsyncMaxPending(p.js.MaxInflight))
+		if err != nil {
+			conn.Close()
+			prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "nats")
+			return err
+		}
+		p.jsCtx = jsCtx
+	}
+	p.conn = conn
+	log.Infof("route: nats producer (re)connected to [%s]", p.urls)
+	return nil
+}
+
+func (p *natsProducer) stateFor(subject string) *subjectState {
+	v, _ := p.subjects.LoadOrStore(subject, &subjectState{})
+	return v.(*subjectState)
+}
+
+// snapshot copies out the connection handles Produce needs, guarding
+// against a concurrent Close() nilling p.conn mid-publish.
+func (p *natsProducer) snapshot() (conn *nats.Conn, jsCtx nats.JetStreamContext) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.conn, p.jsCtx
+}
+
+// Produce publishes payload to subject, using JetStream with PubAck
+// confirmation when enabled, or core NATS fire-and-forget pub
+// otherwise. A single attempt is made; Event2queue's outer retry policy
+// is responsible for retrying on failure.
+func (p *natsProducer) Produce(ctx context.Context, subject string, payload []byte) error {
+	if err := p.Connect(); err != nil {
+		prometheus.CountLabeled(prometheus.KeyNatsPublishErrors, 1, subject, "connect")
+		return err
+	}
+
+	conn, jsCtx := p.snapshot()
+
+	if !p.js.Enabled {
+		if err := conn.Publish(subject, payload); err != nil {
+			prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "nats")
+			prometheus.CountLabeled(prometheus.KeyNatsPublishErrors, 1, subject, "publish")
+			return err
+		}
+		return nil
+	}
+
+	return p.publishJetStream(ctx, jsCtx, subject, payload)
+}
+
+// decInflight drops subject's in-flight count by one and republishes
+// the gauge; call it once the publish is no longer awaiting an ack,
+// nak, or timeout (i.e. at each select branch below), not when it's
+// merely been handed to PublishAsync.
+func (state *subjectState) decInflight(subject string) {
+	state.mux.Lock()
+	state.inflight--
+	depth := state.inflight
+	state.mux.Unlock()
+	prometheus.SetLabeledGauge(prometheus.KeyPublishInflight, float64(depth), subject)
+}
+
+func (p *natsProducer) publishJetStream(ctx context.Context, jsCtx nats.JetStreamContext, subject string, payload []byte) error {
+	state := p.stateFor(subject)
+
+	state.mux.Lock()
+	state.inflight++
+	prometheus.SetLabeledGauge(prometheus.KeyPublishInflight, float64(state.inflight), subject)
+	state.mux.Unlock()
+
+	start := time.Now()
+	future, err := jsCtx.PublishAsync(subject, payload)
+	if err != nil {
+		state.decInflight(subject)
+		prometheus.CountLabeled(prometheus.KeyNatsPublishErrors, 1, subject, "publish")
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "nats")
+		return err
+	}
+
+	select {
+	case <-future.Ok():
+		state.decInflight(subject)
+		prometheus.ObserveHistogram(prometheus.KeyPublishAckLatency, prometheus.DefaultAckLatencyBuckets, time.Since(start).Seconds(), subject)
+		return nil
+	case err = <-future.Err():
+		state.decInflight(subject)
+		prometheus.CountLabeled(prometheus.KeyNatsPublishErrors, 1, subject, "nak")
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "nats")
+		reportPoolError(fmt.Errorf("route: publish to %s nacked: %v", subject, err))
+		return err
+	case <-ctx.Done():
+		state.decInflight(subject)
+		return ctx.Err()
+	case <-time.After(p.js.AckWait):
+		state.decInflight(subject)
+		err = fmt.Errorf("ack timeout for subject %s", subject)
+		prometheus.CountLabeled(prometheus.KeyNatsPublishErrors, 1, subject, "timeout")
+		prometheus.CountLabeled(prometheus.KeyBackendErrors, 1, "nats")
+		reportPoolError(err)
+		return err
+	}
+}
+
+// Close releases the underlying NATS connection.
+func (p *natsProducer) Close() error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package route
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/QOSGroup/cassini/prometheus"
+)
+
+// breakerState mirrors the classic closed/open/half-open circuit
+// breaker states.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// BreakerPolicy configures when a subject's circuit breaker trips and
+// how long it stays open before letting one probe attempt through.
+type BreakerPolicy struct {
+	FailureThreshold int
+	CoolDown         time.Duration
+}
+
+// Breaker is the circuit breaker policy applied per subject. It is
+// populated from config before the first call.
+var Breaker = BreakerPolicy{
+	FailureThreshold: 5,
+	CoolDown:         10 * time.Second,
+}
+
+func (p BreakerPolicy) withDefaults() BreakerPolicy {
+	if p.FailureThreshold <= 0 {
+		p.FailureThreshold = 5
+	}
+	if p.CoolDown <= 0 {
+		p.CoolDown = 10 * time.Second
+	}
+	return p
+}
+
+// circuitBreaker trips a subject to "open" after FailureThreshold
+// consecutive publish failures, rejecting further publishes until
+// CoolDown has elapsed, then lets a single half-open probe through.
+type circuitBreaker struct {
+	policy BreakerPolicy
+
+	mux      sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+var breakers sync.Map // map[string]*circuitBreaker
+
+func breakerFor(subject string) *circuitBreaker {
+	v, _ := breakers.LoadOrStore(subject, &circuitBreaker{policy: Breaker.withDefaults()})
+	return v.(*circuitBreaker)
+}
+
+// allow reports whether a publish to subject may proceed. An open
+// breaker lets through a single canary call once its cool-down has
+// elapsed, flipping to half-open in the same step; every other caller
+// is rejected until recordResult resolves that trial one way or the
+// other, so a recovering backend is never flooded by every goroutine
+// that was waiting on the breaker.
+func (b *circuitBreaker) allow(subject string) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	switch b.state {
+	case breakerHalfOpen:
+		return false
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.policy.CoolDown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.report(subject)
+		return true
+	default:
+		return true
+	}
+}
+
+// recordResult transitions the breaker based on the outcome of an
+// allowed publish attempt.
+func (b *circuitBreaker) recordResult(subject string, err error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		b.state = breakerClosed
+		b.report(subject)
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.policy.FailureThreshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+	b.report(subject)
+}
+
+// report publishes the breaker's current state; callers must hold b.mux.
+func (b *circuitBreaker) report(subject string) {
+	prometheus.SetLabeledGauge(prometheus.KeyBreakerState, float64(b.state), subject)
+}
+
+// errBreakerOpen is returned when a publish is rejected because the
+// subject's circuit breaker is open.
+type errBreakerOpen string
+
+func (e errBreakerOpen) Error() string {
+	return fmt.Sprintf("route: circuit breaker open for subject %s", string(e))
+}
@@ -0,0 +1,81 @@
+package route
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestBreaker(threshold int, coolDown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		policy: BreakerPolicy{FailureThreshold: threshold, CoolDown: coolDown},
+	}
+}
+
+func TestCircuitBreakerClosedAllowsUntilThreshold(t *testing.T) {
+	b := newTestBreaker(3, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if !b.allow("s") {
+			t.Fatalf("attempt %d: expected closed breaker to allow", i)
+		}
+		b.recordResult("s", errTest)
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("state = %v, want closed before threshold is reached", b.state)
+	}
+
+	b.recordResult("s", errTest)
+	if b.state != breakerOpen {
+		t.Fatalf("state = %v, want open once FailureThreshold consecutive failures recorded", b.state)
+	}
+}
+
+func TestCircuitBreakerOpenRejectsUntilCoolDown(t *testing.T) {
+	b := newTestBreaker(1, 50*time.Millisecond)
+	b.recordResult("s", errTest)
+	if b.state != breakerOpen {
+		t.Fatalf("state = %v, want open", b.state)
+	}
+
+	if b.allow("s") {
+		t.Fatal("expected open breaker to reject before CoolDown elapses")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if !b.allow("s") {
+		t.Fatal("expected open breaker to let one canary through after CoolDown")
+	}
+	if b.state != breakerHalfOpen {
+		t.Fatalf("state = %v, want half-open after the canary is let through", b.state)
+	}
+}
+
+func TestCircuitBreakerHalfOpenRejectsConcurrentCallers(t *testing.T) {
+	b := newTestBreaker(1, 0)
+	b.state = breakerHalfOpen
+
+	if b.allow("s") {
+		t.Fatal("expected half-open breaker to reject every caller but the canary already let through")
+	}
+}
+
+func TestCircuitBreakerRecordResultResolvesHalfOpen(t *testing.T) {
+	b := newTestBreaker(1, 0)
+	b.state = breakerHalfOpen
+
+	b.recordResult("s", nil)
+	if b.state != breakerClosed {
+		t.Fatalf("state = %v, want closed after a successful half-open trial", b.state)
+	}
+	if b.failures != 0 {
+		t.Fatalf("failures = %d, want reset to 0 on success", b.failures)
+	}
+
+	b.state = breakerHalfOpen
+	b.recordResult("s", errTest)
+	if b.state != breakerOpen {
+		t.Fatalf("state = %v, want back to open after a failed half-open trial", b.state)
+	}
+}
+
+var errTest = errBreakerOpen("test")
@@ -0,0 +1,66 @@
+package route
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	pool     sync.Map // map[string]Producer, keyed by broker urls
+	poolErrC chan<- error
+)
+
+// SetErrorChannel wires (re)connect failures of pooled producers, and
+// JetStream nak/ack-timeout errors, to an error channel, following the
+// same pattern as the prometheus collector's SetErrorChannel. Both are
+// reported from within the Event2queue call that hit them.
+func SetErrorChannel(ch chan<- error) {
+	poolErrC = ch
+}
+
+func reportPoolError(err error) {
+	if poolErrC == nil || err == nil {
+		return
+	}
+	poolErrC <- err
+}
+
+// acquireProducer returns the long-lived, backend-agnostic Producer for
+// urls, lazily creating it on first use. It does not connect the
+// producer; see ensureConnected.
+func acquireProducer(urls string) (Producer, error) {
+	if v, ok := pool.Load(urls); ok {
+		return v.(Producer), nil
+	}
+
+	producer, err := newProducer(urls)
+	if err != nil {
+		return nil, err
+	}
+	actual, loaded := pool.LoadOrStore(urls, producer)
+	if loaded {
+		// Another goroutine won the race; discard the one we built.
+		producer.Close()
+	}
+	return actual.(Producer), nil
+}
+
+// ensureConnected connects producer if it is not already usable,
+// reporting any failure through the pool's error channel.
+func ensureConnected(urls string, producer Producer) error {
+	if err := producer.Connect(); err != nil {
+		reportPoolError(fmt.Errorf("pool: (re)connect to %s failed: %v", urls, err))
+		return err
+	}
+	return nil
+}
+
+// Close tears down every pooled producer connection. It is intended to
+// be called once on shutdown.
+func Close() {
+	pool.Range(func(k, v interface{}) bool {
+		v.(Producer).Close()
+		pool.Delete(k)
+		return true
+	})
+}
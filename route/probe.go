@@ -0,0 +1,41 @@
+package route
+
+import (
+	"context"
+
+	"github.com/QOSGroup/cassini/prometheus"
+)
+
+func init() {
+	prometheus.RegisterProbe(&poolProbe{})
+}
+
+// poolProbe is route's self-registered contribution to the prometheus
+// collector's probe scrape: it reports, per subject, how many
+// JetStream publishes are currently in flight and awaiting ack as
+// cassini_queue_depth.
+type poolProbe struct{}
+
+func (*poolProbe) Name() string { return "route.pool" }
+
+func (*poolProbe) Update(ctx context.Context) error {
+	pool.Range(func(_, v interface{}) bool {
+		np, ok := v.(*natsProducer)
+		if !ok {
+			return true
+		}
+		np.subjects.Range(func(sk, sv interface{}) bool {
+			subject := sk.(string)
+			state := sv.(*subjectState)
+
+			state.mux.Lock()
+			depth := state.inflight
+			state.mux.Unlock()
+
+			prometheus.SetLabeledGauge(prometheus.KeyQueueDepth, float64(depth), subject)
+			return true
+		})
+		return true
+	})
+	return nil
+}
@@ -0,0 +1,69 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrNoBrokers is returned by a backend's Connect when urls does not
+// name at least one broker address.
+var ErrNoBrokers = errors.New("route: no broker address given")
+
+// Producer is the backend-agnostic interface that Event2queue publishes
+// through. Each message-queue backend (NATS, Kafka, RabbitMQ, ...)
+// implements it and registers itself with Register.
+type Producer interface {
+	// Connect establishes (or re-establishes) the backend connection.
+	Connect() error
+	// Produce publishes payload to subject.
+	Produce(ctx context.Context, subject string, payload []byte) error
+	// Close releases the backend connection.
+	Close() error
+}
+
+// Factory creates a new, unconnected Producer for the given broker urls.
+type Factory func(urls string) Producer
+
+var factories = make(map[string]Factory)
+
+// Register adds a backend factory under name (e.g. "nats", "kafka",
+// "amqp") so Event2queue can pick it by config/URL scheme.
+func Register(name string, factory Factory) {
+	factories[name] = factory
+}
+
+// defaultScheme is the backend picked for urls with no "scheme://"
+// prefix, preserving the behavior of configs written before the
+// pluggable backend was introduced (plain "host:port" or comma-joined
+// broker lists, which always meant NATS).
+const defaultScheme = "nats"
+
+// scheme returns the URL scheme used to pick a backend, e.g. "nats" for
+// "nats://127.0.0.1:4222", falling back to defaultScheme for urls with
+// no scheme (or one too short to be a scheme, e.g. a bare
+// "127.0.0.1:4222" that url.Parse would otherwise reject, or
+// "localhost:4222" that url.Parse would otherwise misread as
+// scheme "localhost").
+func scheme(urls string) (string, error) {
+	u, err := url.Parse(urls)
+	if err != nil || u.Scheme == "" || u.Opaque != "" {
+		return defaultScheme, nil
+	}
+	return u.Scheme, nil
+}
+
+// newProducer picks a backend by the urls' scheme and builds an
+// unconnected Producer for it.
+func newProducer(urls string) (Producer, error) {
+	s, err := scheme(urls)
+	if err != nil {
+		return nil, err
+	}
+	factory, ok := factories[s]
+	if !ok {
+		return nil, fmt.Errorf("route: no backend registered for scheme %q", s)
+	}
+	return factory(urls), nil
+}
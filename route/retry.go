@@ -0,0 +1,83 @@
+package route
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/QOSGroup/cassini/log"
+	"github.com/QOSGroup/cassini/prometheus"
+)
+
+// RetryPolicy bounds the retry-with-backoff wrapped around a producer's
+// Connect and Produce calls.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Jitter         float64 // fraction of the backoff to randomize, e.g. 0.2
+}
+
+// Policy is the retry policy applied to every Event2queue publish. It is
+// populated from config before the first call.
+var Policy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Jitter:         0.2,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 1
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = p.InitialBackoff
+	}
+	return p
+}
+
+// retry runs fn up to policy.MaxAttempts times with exponential
+// backoff, logging every attempt and, if every attempt fails,
+// aggregating them into a single returned error.
+func retry(policy RetryPolicy, subject, op string, fn func() error) error {
+	policy = policy.withDefaults()
+	backoff := policy.InitialBackoff
+	var errs []string
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		errs = append(errs, fmt.Sprintf("attempt %d: %v", attempt, err))
+		log.Infof("route: %s [%s] failed (attempt %d/%d): %v",
+			op, subject, attempt, policy.MaxAttempts, err)
+
+		if attempt == policy.MaxAttempts {
+			break
+		}
+		prometheus.CountLabeled(prometheus.KeyPublishRetries, 1, subject)
+		time.Sleep(jitter(backoff, policy.Jitter))
+		backoff *= 2
+		if backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+	return fmt.Errorf("route: %s [%s] failed after %d attempts: %s",
+		op, subject, policy.MaxAttempts, strings.Join(errs, "; "))
+}
+
+// jitter randomizes d by +/- frac, used to avoid retry storms across
+// many subjects backing off in lockstep.
+func jitter(d time.Duration, frac float64) time.Duration {
+	if frac <= 0 {
+		return d
+	}
+	delta := float64(d) * frac
+	return d + time.Duration(rand.Float64()*2*delta-delta)
+}
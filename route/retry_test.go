@@ -0,0 +1,80 @@
+package route
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetrySucceedsWithoutRetryingOnFirstAttempt(t *testing.T) {
+	calls := 0
+	err := retry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}, "s", "op", func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("retry() = %v, want nil", err)
+	}
+	if calls != 1 {
+		t.Fatalf("fn called %d times, want 1", calls)
+	}
+}
+
+func TestRetryStopsAtFirstSuccess(t *testing.T) {
+	calls := 0
+	err := retry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}, "s", "op", func() error {
+		calls++
+		if calls == 2 {
+			return nil
+		}
+		return errors.New("not yet")
+	})
+	if err != nil {
+		t.Fatalf("retry() = %v, want nil", err)
+	}
+	if calls != 2 {
+		t.Fatalf("fn called %d times, want 2", calls)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	err := retry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}, "s", "op", func() error {
+		calls++
+		return errors.New("boom")
+	})
+	if calls != 3 {
+		t.Fatalf("fn called %d times, want MaxAttempts=3", calls)
+	}
+	if err == nil {
+		t.Fatal("retry() = nil, want an aggregated error after exhausting MaxAttempts")
+	}
+	if !strings.Contains(err.Error(), "attempt 1") || !strings.Contains(err.Error(), "attempt 3") {
+		t.Fatalf("err = %q, want every attempt's failure included", err.Error())
+	}
+}
+
+func TestJitterWithinBounds(t *testing.T) {
+	d := 100 * time.Millisecond
+	frac := 0.2
+	low := time.Duration(float64(d) * (1 - frac))
+	high := time.Duration(float64(d) * (1 + frac))
+
+	for i := 0; i < 100; i++ {
+		j := jitter(d, frac)
+		if j < low || j > high {
+			t.Fatalf("jitter(%v, %v) = %v, want within [%v, %v]", d, frac, j, low, high)
+		}
+	}
+}
+
+func TestJitterNoopWhenFracNotPositive(t *testing.T) {
+	d := 100 * time.Millisecond
+	if got := jitter(d, 0); got != d {
+		t.Fatalf("jitter(d, 0) = %v, want %v unchanged", got, d)
+	}
+	if got := jitter(d, -1); got != d {
+		t.Fatalf("jitter(d, -1) = %v, want %v unchanged", got, d)
+	}
+}
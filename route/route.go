@@ -1,40 +1,65 @@
 package route
 
 import (
+	"context"
 	"errors"
 
 	"github.com/QOSGroup/cassini/log"
-	mq "github.com/QOSGroup/cassini/msgqueue"
+	"github.com/QOSGroup/cassini/prometheus"
 	"github.com/QOSGroup/cassini/types"
 	"github.com/tendermint/go-amino"
 )
 
 //type route struct{}
 
-// Event2queue produce event to message queue (Nats)
-func Event2queue(nats string, event *types.Event) (subject string, err error) {
+// Event2queue produce event to message queue. The backend (NATS, Kafka,
+// RabbitMQ, ...) is picked from urls' scheme, e.g. "nats://127.0.0.1:4222"
+// or "kafka://127.0.0.1:9092". Connect and publish are each retried with
+// backoff per Policy, and guarded by a per-subject circuit breaker
+// configured by Breaker.
+func Event2queue(urls string, event *types.Event) (subject string, err error) {
 
 	if event == nil || event.HashBytes == nil || event.From == "" || event.To == "" || event.NodeAddress == "" {
 
 		return "", errors.New("event is nil")
 	}
 
+	defer func() {
+		status := "ok"
+		if err != nil {
+			status = "error"
+		}
+		prometheus.CountLabeled(prometheus.KeyTxRelayedTotal, 1, event.From, event.To, status)
+	}()
+
 	eventbytes, _ := amino.MarshalBinaryLengthPrefixed(*event)
 
 	subject = event.From + "2" + event.To
 
-	producer := mq.NATSProducer{ServerUrls: nats, Subject: subject}
-
-	np, err := producer.Connect() //TODO don't connect every time
+	breaker := breakerFor(subject)
+	if !breaker.allow(subject) {
+		return "", errBreakerOpen(subject)
+	}
 
+	producer, err := acquireProducer(urls)
 	if err != nil {
-
-		return "", errors.New("couldn't connect to msg server")
+		breaker.recordResult(subject, err)
+		return "", err
 	}
 
-	defer np.Close()
+	err = retry(Policy, subject, "connect", func() error {
+		return ensureConnected(urls, producer)
+	})
+	if err != nil {
+		breaker.recordResult(subject, err)
+		return "", err
+	}
 
-	if err := producer.Produce(np, eventbytes); err != nil {
+	err = retry(Policy, subject, "produce", func() error {
+		return producer.Produce(context.Background(), subject, eventbytes)
+	})
+	breaker.recordResult(subject, err)
+	if err != nil {
 		return "", err
 	}
 